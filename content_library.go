@@ -0,0 +1,188 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vapi/library"
+	"github.com/vmware/govmomi/vapi/rest"
+	"github.com/vmware/govmomi/vapi/vcenter"
+)
+
+// ContentLibraryConfig locates a Content Library item and describes where a
+// VM deployed from it should land.
+type ContentLibraryConfig struct {
+	// Library is the Content Library name.
+	Library string
+	// Item is the name of the OVF or VM template item within Library.
+	Item string
+
+	VMName       string
+	FolderName   string
+	Host         string
+	Cluster      string
+	ResourcePool string
+	Datastore    string
+}
+
+// PublishContentLibraryConfig describes where a built VM should be published
+// back to as a new library item.
+type PublishContentLibraryConfig struct {
+	Library     string
+	Name        string
+	Description string
+	// Destroy removes the source VM once it has been captured into the
+	// library, matching Packer's usual "artifact only" builder contract.
+	Destroy bool
+}
+
+// restClient lazily authenticates against the vCenter REST endpoint
+// (vapi/rest), reusing the SOAP session's credentials.
+func (d *Driver) restClient() (*rest.Client, error) {
+	c := rest.NewClient(d.client.Client)
+	if err := c.Login(d.ctx, d.client.URL().User); err != nil {
+		return nil, fmt.Errorf("error authenticating to vCenter REST endpoint: %s", err)
+	}
+	return c, nil
+}
+
+// DeployFromContentLibrary deploys a VM from a Content Library OVF or VM
+// template item, looking the item up by library name and item name with
+// findLibraryItem and dispatching to DeployLibraryItem or DeployTemplateLibraryItem
+// depending on its type. This is the Content Library equivalent of cloneVM.
+func (d *Driver) DeployFromContentLibrary(config *ContentLibraryConfig) (*object.VirtualMachine, error) {
+	c, err := d.restClient()
+	if err != nil {
+		return nil, err
+	}
+	defer c.Logout(d.ctx)
+
+	item, err := d.findLibraryItem(c, config.Library, config.Item)
+	if err != nil {
+		return nil, err
+	}
+
+	folder, err := d.finder.FolderOrDefault(d.ctx, fmt.Sprintf("/%v/vm/%v", d.datacenter.Name(), config.FolderName))
+	if err != nil {
+		return nil, err
+	}
+	pool, err := d.finder.ResourcePoolOrDefault(d.ctx, fmt.Sprintf("/%v/host/%v/Resources/%v", d.datacenter.Name(), config.Host, config.ResourcePool))
+	if err != nil {
+		return nil, err
+	}
+	datastore, err := d.finder.DatastoreOrDefault(d.ctx, config.Datastore)
+	if err != nil {
+		return nil, err
+	}
+
+	manager := vcenter.NewManager(c)
+
+	switch item.Type {
+	case library.ItemTypeOVF:
+		deploy := vcenter.Deploy{
+			DeploymentSpec: vcenter.DeploymentSpec{
+				Name:               config.VMName,
+				DefaultDatastoreID: datastore.Reference().Value,
+				AcceptAllEULA:      true,
+			},
+			Target: vcenter.Target{
+				ResourcePoolID: pool.Reference().Value,
+				FolderID:       folder.Reference().Value,
+			},
+		}
+		ref, err := manager.DeployLibraryItem(d.ctx, item.ID, deploy)
+		if err != nil {
+			return nil, fmt.Errorf("error deploying OVF library item %q: %s", config.Item, err)
+		}
+		return object.NewVirtualMachine(d.client.Client, ref.Reference()), nil
+	case library.ItemTypeVMTX:
+		spec := vcenter.DeployTemplate{
+			Name: config.VMName,
+			Placement: &library.Placement{
+				Cluster:      config.Cluster,
+				Folder:       folder.Reference().Value,
+				ResourcePool: pool.Reference().Value,
+			},
+			VMHomeStorage: &vcenter.DiskStorage{
+				Datastore: datastore.Reference().Value,
+			},
+		}
+		ref, err := manager.DeployTemplateLibraryItem(d.ctx, item.ID, spec)
+		if err != nil {
+			return nil, fmt.Errorf("error deploying VM template library item %q: %s", config.Item, err)
+		}
+		return object.NewVirtualMachine(d.client.Client, ref.Reference()), nil
+	default:
+		return nil, fmt.Errorf("unsupported content library item type %q for %q", item.Type, config.Item)
+	}
+}
+
+// PublishToContentLibrary captures a freshly built VM as a new item in a
+// Content Library, so subsequent builds can source from it in place of an
+// inventory template.
+func (d *Driver) PublishToContentLibrary(vm *object.VirtualMachine, config *PublishContentLibraryConfig) error {
+	c, err := d.restClient()
+	if err != nil {
+		return err
+	}
+	defer c.Logout(d.ctx)
+
+	lib, err := d.findLibraryByName(c, config.Library)
+	if err != nil {
+		return err
+	}
+
+	spec := vcenter.Template{
+		Name:     config.Name,
+		Library:  lib.ID,
+		SourceVM: vm.Reference().Value,
+	}
+	if config.Description != "" {
+		spec.Description = config.Description
+	}
+
+	if _, err := vcenter.NewManager(c).CreateTemplate(d.ctx, spec); err != nil {
+		return fmt.Errorf("error publishing VM to content library %q: %s", config.Library, err)
+	}
+
+	if config.Destroy {
+		return d.destroyVM(vm, nil)
+	}
+	return nil
+}
+
+func (d *Driver) findLibraryByName(c *rest.Client, name string) (*library.Library, error) {
+	m := library.NewManager(c)
+	libs, err := m.GetLibraries(d.ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range libs {
+		if libs[i].Name == name {
+			return &libs[i], nil
+		}
+	}
+	return nil, fmt.Errorf("content library %q not found", name)
+}
+
+// findLibraryItem looks up the library named libraryName and returns its first
+// item whose name is exactly itemName. It does not use the Content Library
+// finder, so library/item paths and wildcards are not resolved.
+func (d *Driver) findLibraryItem(c *rest.Client, libraryName, itemName string) (*library.Item, error) {
+	lib, err := d.findLibraryByName(c, libraryName)
+	if err != nil {
+		return nil, err
+	}
+
+	m := library.NewManager(c)
+	items, err := m.GetLibraryItems(d.ctx, lib.ID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range items {
+		if items[i].Name == itemName {
+			return &items[i], nil
+		}
+	}
+	return nil, fmt.Errorf("item %q not found in content library %q", itemName, libraryName)
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// GuestCustomizationConfig describes the guest identity and network settings
+// that should be applied to a VM as part of cloning, mirroring the options
+// exposed by vCenter's CustomizationSpec.
+type GuestCustomizationConfig struct {
+	// Hostname to assign to the guest. For Windows guests this becomes the
+	// computer name unless Windows.ComputerName is set.
+	Hostname string
+	// Domain is the Linux guest's DNS domain. It is not used for Windows
+	// guests, which join a domain only through Windows.JoinDomain.
+	Domain string
+	// LinuxTimezone is the Linux/Unix timezone area (e.g. "America/Los_Angeles").
+	LinuxTimezone string
+	// DNSServers lists nameservers applied globally to the guest.
+	DNSServers []string
+	// DNSSuffixes lists DNS search suffixes applied globally to the guest.
+	DNSSuffixes []string
+
+	// NetworkInterfaces are applied to the guest NICs in order. If empty,
+	// DHCP is used for every adapter.
+	NetworkInterfaces []NetworkInterfaceCustomization
+
+	// Windows-only fields. Windows is nil for Linux guests.
+	Windows *WindowsCustomizationOptions
+}
+
+// NetworkInterfaceCustomization configures a single guest NIC. When IPv4Address
+// is empty the adapter is configured for DHCP.
+type NetworkInterfaceCustomization struct {
+	IPv4Address string
+	IPv4Netmask string
+	IPv4Gateway string
+
+	IPv6Address string
+	IPv6Prefix  int
+	IPv6Gateway string
+}
+
+// WindowsCustomizationOptions carries the Sysprep identification fields
+// required to customize a Windows guest.
+type WindowsCustomizationOptions struct {
+	ComputerName   string
+	FullName       string
+	OrgName        string
+	ProductKey     string
+	AdminPassword  string
+	AutoLogon      bool
+	AutoLogonCount int32
+
+	// JoinDomain, when set, causes the guest to join an Active Directory
+	// domain instead of the workgroup named in Workgroup.
+	JoinDomain     string
+	DomainUsername string
+	DomainPassword string
+	Workgroup      string
+}
+
+// toCustomizationSpec translates a GuestCustomizationConfig into the
+// types.CustomizationSpec vSphere expects on a clone or CustomizeVM_Task call.
+func (c *GuestCustomizationConfig) toCustomizationSpec() types.CustomizationSpec {
+	adapterMapping := make([]types.CustomizationAdapterMapping, len(c.NetworkInterfaces))
+	for i, nic := range c.NetworkInterfaces {
+		adapterMapping[i] = nic.toAdapterMapping()
+	}
+
+	globalIPSettings := types.CustomizationGlobalIPSettings{
+		DnsServerList: c.DNSServers,
+		DnsSuffixList: c.DNSSuffixes,
+	}
+
+	var identity types.BaseCustomizationIdentitySettings
+	if c.Windows != nil {
+		identity = c.Windows.toIdentification(c.Hostname, c.Domain)
+	} else {
+		identity = &types.CustomizationLinuxPrep{
+			HostName: &types.CustomizationFixedName{Name: c.Hostname},
+			Domain:   c.Domain,
+			TimeZone: c.LinuxTimezone,
+		}
+	}
+
+	return types.CustomizationSpec{
+		Identity:         identity,
+		GlobalIPSettings: globalIPSettings,
+		NicSettingMap:    adapterMapping,
+	}
+}
+
+func (n *NetworkInterfaceCustomization) toAdapterMapping() types.CustomizationAdapterMapping {
+	if n.IPv4Address == "" && n.IPv6Address == "" {
+		return types.CustomizationAdapterMapping{
+			Adapter: types.CustomizationIPSettings{
+				Ip: &types.CustomizationDhcpIpGenerator{},
+			},
+		}
+	}
+
+	settings := types.CustomizationIPSettings{}
+	if n.IPv4Address != "" {
+		settings.Ip = &types.CustomizationFixedIp{IpAddress: n.IPv4Address}
+		settings.SubnetMask = n.IPv4Netmask
+		if n.IPv4Gateway != "" {
+			settings.Gateway = []string{n.IPv4Gateway}
+		}
+	} else {
+		settings.Ip = &types.CustomizationDhcpIpGenerator{}
+	}
+
+	if n.IPv6Address != "" {
+		settings.IpV6Spec = &types.CustomizationIPSettingsIpV6AddressSpec{
+			Ip: []types.BaseCustomizationIpV6Generator{
+				&types.CustomizationFixedIpV6{
+					IpAddress: n.IPv6Address,
+					SubnetMask: int32(n.IPv6Prefix),
+				},
+			},
+		}
+		if n.IPv6Gateway != "" {
+			settings.IpV6Spec.Gateway = []string{n.IPv6Gateway}
+		}
+	}
+
+	return types.CustomizationAdapterMapping{Adapter: settings}
+}
+
+func (w *WindowsCustomizationOptions) toIdentification(hostname, domain string) types.BaseCustomizationIdentitySettings {
+	computerName := w.ComputerName
+	if computerName == "" {
+		computerName = hostname
+	}
+
+	sysprep := &types.CustomizationSysprep{
+		GuiUnattended: types.CustomizationGuiUnattended{
+			AutoLogon:      w.AutoLogon,
+			AutoLogonCount: w.AutoLogonCount,
+			Password: &types.CustomizationPassword{
+				Value:     w.AdminPassword,
+				PlainText: true,
+			},
+		},
+		UserData: types.CustomizationUserData{
+			ComputerName: &types.CustomizationFixedName{Name: computerName},
+			FullName:     w.FullName,
+			OrgName:      w.OrgName,
+			ProductId:    w.ProductKey,
+		},
+		Identification: types.CustomizationIdentification{
+			JoinWorkgroup: w.Workgroup,
+		},
+	}
+
+	if w.JoinDomain != "" {
+		sysprep.Identification.JoinDomain = w.JoinDomain
+		sysprep.Identification.DomainAdmin = w.DomainUsername
+		sysprep.Identification.DomainAdminPassword = &types.CustomizationPassword{
+			Value:     w.DomainPassword,
+			PlainText: true,
+		}
+		sysprep.Identification.JoinWorkgroup = ""
+	}
+
+	return sysprep
+}
+
+// CustomizeVM applies guest customization to an already-cloned VM via
+// CustomizeVM_Task and waits for the task to complete. This is equivalent to
+// attaching a Customization block to the clone spec, but can also be called
+// against a VM that was cloned without one.
+func (d *Driver) CustomizeVM(vm *object.VirtualMachine, config *GuestCustomizationConfig) error {
+	spec := config.toCustomizationSpec()
+
+	task, err := vm.Customize(d.ctx, spec)
+	if err != nil {
+		return err
+	}
+
+	if _, err := task.WaitForResult(d.ctx, nil); err != nil {
+		return fmt.Errorf("error customizing VM: %s", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func TestToAdapterMappingDHCP(t *testing.T) {
+	nic := NetworkInterfaceCustomization{}
+
+	mapping := nic.toAdapterMapping()
+
+	if _, ok := mapping.Adapter.Ip.(*types.CustomizationDhcpIpGenerator); !ok {
+		t.Fatalf("expected DHCP generator for empty NIC config, got %T", mapping.Adapter.Ip)
+	}
+}
+
+func TestToAdapterMappingStaticIPv4(t *testing.T) {
+	nic := NetworkInterfaceCustomization{
+		IPv4Address: "192.168.1.10",
+		IPv4Netmask: "255.255.255.0",
+		IPv4Gateway: "192.168.1.1",
+	}
+
+	mapping := nic.toAdapterMapping()
+
+	fixedIP, ok := mapping.Adapter.Ip.(*types.CustomizationFixedIp)
+	if !ok {
+		t.Fatalf("expected fixed IP for static NIC config, got %T", mapping.Adapter.Ip)
+	}
+	if fixedIP.IpAddress != nic.IPv4Address {
+		t.Errorf("IpAddress = %q, want %q", fixedIP.IpAddress, nic.IPv4Address)
+	}
+	if mapping.Adapter.SubnetMask != nic.IPv4Netmask {
+		t.Errorf("SubnetMask = %q, want %q", mapping.Adapter.SubnetMask, nic.IPv4Netmask)
+	}
+	if len(mapping.Adapter.Gateway) != 1 || mapping.Adapter.Gateway[0] != nic.IPv4Gateway {
+		t.Errorf("Gateway = %v, want [%q]", mapping.Adapter.Gateway, nic.IPv4Gateway)
+	}
+}
+
+func TestToAdapterMappingIPv6(t *testing.T) {
+	nic := NetworkInterfaceCustomization{
+		IPv6Address: "2001:db8::10",
+		IPv6Prefix:  64,
+		IPv6Gateway: "2001:db8::1",
+	}
+
+	mapping := nic.toAdapterMapping()
+
+	if mapping.Adapter.IpV6Spec == nil || len(mapping.Adapter.IpV6Spec.Ip) != 1 {
+		t.Fatalf("expected one IPv6 address generator, got %v", mapping.Adapter.IpV6Spec)
+	}
+	fixedIPv6, ok := mapping.Adapter.IpV6Spec.Ip[0].(*types.CustomizationFixedIpV6)
+	if !ok {
+		t.Fatalf("expected fixed IPv6 address, got %T", mapping.Adapter.IpV6Spec.Ip[0])
+	}
+	if fixedIPv6.IpAddress != nic.IPv6Address {
+		t.Errorf("IpAddress = %q, want %q", fixedIPv6.IpAddress, nic.IPv6Address)
+	}
+	if fixedIPv6.SubnetMask != int32(nic.IPv6Prefix) {
+		t.Errorf("SubnetMask = %d, want %d", fixedIPv6.SubnetMask, nic.IPv6Prefix)
+	}
+}
+
+func TestToCustomizationSpecLinux(t *testing.T) {
+	config := GuestCustomizationConfig{
+		Hostname:      "web01",
+		Domain:        "example.com",
+		LinuxTimezone: "America/Los_Angeles",
+		DNSServers:    []string{"8.8.8.8"},
+		DNSSuffixes:   []string{"example.com"},
+	}
+
+	spec := config.toCustomizationSpec()
+
+	linuxPrep, ok := spec.Identity.(*types.CustomizationLinuxPrep)
+	if !ok {
+		t.Fatalf("expected CustomizationLinuxPrep identity, got %T", spec.Identity)
+	}
+	fixedName, ok := linuxPrep.HostName.(*types.CustomizationFixedName)
+	if !ok || fixedName.Name != config.Hostname {
+		t.Errorf("HostName = %v, want fixed name %q", linuxPrep.HostName, config.Hostname)
+	}
+	if linuxPrep.Domain != config.Domain {
+		t.Errorf("Domain = %q, want %q", linuxPrep.Domain, config.Domain)
+	}
+	if linuxPrep.TimeZone != config.LinuxTimezone {
+		t.Errorf("TimeZone = %q, want %q", linuxPrep.TimeZone, config.LinuxTimezone)
+	}
+	if len(spec.GlobalIPSettings.DnsServerList) != 1 || spec.GlobalIPSettings.DnsServerList[0] != "8.8.8.8" {
+		t.Errorf("DnsServerList = %v, want [8.8.8.8]", spec.GlobalIPSettings.DnsServerList)
+	}
+}
+
+func TestToCustomizationSpecWindowsWorkgroup(t *testing.T) {
+	config := GuestCustomizationConfig{
+		Hostname: "win01",
+		Windows: &WindowsCustomizationOptions{
+			ComputerName: "WIN01",
+			Workgroup:    "WORKGROUP",
+		},
+	}
+
+	spec := config.toCustomizationSpec()
+
+	sysprep, ok := spec.Identity.(*types.CustomizationSysprep)
+	if !ok {
+		t.Fatalf("expected CustomizationSysprep identity, got %T", spec.Identity)
+	}
+	if sysprep.Identification.JoinWorkgroup != "WORKGROUP" {
+		t.Errorf("JoinWorkgroup = %q, want WORKGROUP", sysprep.Identification.JoinWorkgroup)
+	}
+	if sysprep.Identification.JoinDomain != "" {
+		t.Errorf("JoinDomain = %q, want empty for workgroup join", sysprep.Identification.JoinDomain)
+	}
+}
+
+func TestToCustomizationSpecWindowsDomainJoin(t *testing.T) {
+	config := GuestCustomizationConfig{
+		Windows: &WindowsCustomizationOptions{
+			ComputerName:   "WIN01",
+			Workgroup:      "WORKGROUP",
+			JoinDomain:     "corp.example.com",
+			DomainUsername: "admin",
+			DomainPassword: "hunter2",
+		},
+	}
+
+	spec := config.toCustomizationSpec()
+
+	sysprep := spec.Identity.(*types.CustomizationSysprep)
+	if sysprep.Identification.JoinDomain != "corp.example.com" {
+		t.Errorf("JoinDomain = %q, want corp.example.com", sysprep.Identification.JoinDomain)
+	}
+	if sysprep.Identification.JoinWorkgroup != "" {
+		t.Errorf("JoinWorkgroup = %q, want empty when joining a domain", sysprep.Identification.JoinWorkgroup)
+	}
+	if sysprep.Identification.DomainAdmin != "admin" {
+		t.Errorf("DomainAdmin = %q, want admin", sysprep.Identification.DomainAdmin)
+	}
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// NetworkAdapterConfig describes a single NIC to attach to a VM.
+type NetworkAdapterConfig struct {
+	// Network is the Network or DVPortGroup name, resolved relative to the
+	// datacenter via finder.NetworkOrDefault.
+	Network string
+	// AdapterType selects the virtual NIC model: "vmxnet3", "e1000", or
+	// "e1000e".
+	AdapterType string
+}
+
+// SCSIControllerConfig describes a SCSI controller to add to a VM.
+type SCSIControllerConfig struct {
+	// Type is one of the names returned by object.SCSIControllerTypes():
+	// "lsilogic", "lsilogic-sas", "pvscsi", or "buslogic".
+	Type string
+	// BusSharing controls multi-writer access for clustering: "noSharing",
+	// "virtualSharing", or "physicalSharing".
+	BusSharing   string
+	HotAddRemove bool
+}
+
+// DiskConfig describes an additional VMDK to attach to a VM.
+type DiskConfig struct {
+	// SizeGB is the disk capacity in gigabytes.
+	SizeGB int64
+	// Datastore is the name of the datastore to place the disk on. If
+	// empty, the VM's existing datastore is used.
+	Datastore string
+	// Provisioning is one of "thin", "thick", or "eagerZeroedThick".
+	Provisioning string
+	// ControllerKey is the key of the SCSI controller the disk should be
+	// attached to, typically the Key of the device returned by
+	// AddSCSIController.
+	ControllerKey int32
+}
+
+// AddNetworkAdapter attaches a new NIC to vm using the device type and
+// backing network named in config, via a Reconfigure call against the VM's
+// current VirtualDeviceList.
+func (d *Driver) AddNetworkAdapter(vm *object.VirtualMachine, config *NetworkAdapterConfig) (types.BaseVirtualDevice, error) {
+	devices, err := vm.Device(d.ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	network, err := d.finder.NetworkOrDefault(d.ctx, config.Network)
+	if err != nil {
+		return nil, err
+	}
+	backing, err := network.EthernetCardBackingInfo(d.ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	device, err := devices.CreateEthernetCard(config.AdapterType, backing)
+	if err != nil {
+		return nil, fmt.Errorf("error creating network adapter of type %q: %s", config.AdapterType, err)
+	}
+
+	if err := d.addDevice(vm, device); err != nil {
+		return nil, err
+	}
+	return device, nil
+}
+
+// AddSCSIController adds a SCSI controller of the requested type to vm and
+// returns the resulting device so callers can target AddDisk at it.
+func (d *Driver) AddSCSIController(vm *object.VirtualMachine, config *SCSIControllerConfig) (types.BaseVirtualDevice, error) {
+	devices, err := vm.Device(d.ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	device, err := devices.CreateSCSIController(config.Type)
+	if err != nil {
+		return nil, fmt.Errorf("error creating SCSI controller of type %q: %s", config.Type, err)
+	}
+
+	if controller, ok := device.(types.BaseVirtualSCSIController); ok {
+		info := controller.GetVirtualSCSIController()
+		if config.BusSharing != "" {
+			info.SharedBus = types.VirtualSCSISharing(config.BusSharing)
+		}
+		info.HotAddRemove = &config.HotAddRemove
+	}
+
+	if err := d.addDevice(vm, device); err != nil {
+		return nil, err
+	}
+
+	// The key assigned by CreateSCSIController is a placeholder; return the
+	// controller as vCenter recorded it so its Key can be passed to AddDisk.
+	after, err := vm.Device(d.ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, added := range after.SelectByType(device) {
+		if devices.FindByKey(added.GetVirtualDevice().Key) == nil {
+			return added, nil
+		}
+	}
+	return device, nil
+}
+
+// AddDisk attaches a new VMDK to vm on the controller identified by
+// config.ControllerKey, creating it on first attach (file operation
+// "create") rather than attaching a pre-existing disk.
+func (d *Driver) AddDisk(vm *object.VirtualMachine, config *DiskConfig) (types.BaseVirtualDevice, error) {
+	devices, err := vm.Device(d.ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	controller, ok := devices.FindByKey(config.ControllerKey).(types.BaseVirtualController)
+	if !ok {
+		return nil, fmt.Errorf("no controller found with key %d", config.ControllerKey)
+	}
+
+	disk := devices.CreateDisk(controller, types.ManagedObjectReference{}, "")
+	disk.CapacityInKB = diskCapacityKB(config.SizeGB)
+
+	backing := disk.Backing.(*types.VirtualDiskFlatVer2BackingInfo)
+	applyDiskProvisioning(backing, config.Provisioning)
+
+	if config.Datastore != "" {
+		datastore, err := d.finder.Datastore(d.ctx, config.Datastore)
+		if err != nil {
+			return nil, err
+		}
+		datastoreRef := datastore.Reference()
+		backing.Datastore = &datastoreRef
+	}
+
+	spec, err := object.VirtualDeviceList{disk}.ConfigSpec(types.VirtualDeviceConfigSpecOperationAdd)
+	if err != nil {
+		return nil, err
+	}
+	spec[0].GetVirtualDeviceConfigSpec().FileOperation = types.VirtualDeviceConfigSpecFileOperationCreate
+
+	task, err := vm.Reconfigure(d.ctx, types.VirtualMachineConfigSpec{DeviceChange: spec})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := task.WaitForResult(d.ctx, nil); err != nil {
+		return nil, fmt.Errorf("error adding disk: %s", err)
+	}
+
+	return disk, nil
+}
+
+// diskCapacityKB converts a disk size in gigabytes to the kilobytes unit
+// CapacityInKB expects.
+func diskCapacityKB(sizeGB int64) int64 {
+	return sizeGB * 1024 * 1024
+}
+
+// applyDiskProvisioning sets the thin/thick/eagerZeroedThick flags on a flat
+// disk backing to match provisioning, defaulting to "thick" for any other
+// value.
+func applyDiskProvisioning(backing *types.VirtualDiskFlatVer2BackingInfo, provisioning string) {
+	switch provisioning {
+	case "thin":
+		backing.ThinProvisioned = types.NewBool(true)
+	case "eagerZeroedThick":
+		backing.ThinProvisioned = types.NewBool(false)
+		backing.EagerlyScrub = types.NewBool(true)
+	default: // "thick"
+		backing.ThinProvisioned = types.NewBool(false)
+	}
+}
+
+// RemoveDevice detaches device from vm. When device is a disk, the backing
+// VMDK file is left on the datastore unless destroyBacking is set.
+func (d *Driver) RemoveDevice(vm *object.VirtualMachine, device types.BaseVirtualDevice, destroyBacking bool) error {
+	devices := object.VirtualDeviceList{device}
+	spec, err := devices.ConfigSpec(types.VirtualDeviceConfigSpecOperationRemove)
+	if err != nil {
+		return err
+	}
+	if destroyBacking {
+		spec[0].GetVirtualDeviceConfigSpec().FileOperation = types.VirtualDeviceConfigSpecFileOperationDestroy
+	} else {
+		// ConfigSpec sets FileOperation to "destroy" unconditionally for disk
+		// devices; clear it so the backing VMDK is left on the datastore.
+		spec[0].GetVirtualDeviceConfigSpec().FileOperation = ""
+	}
+
+	task, err := vm.Reconfigure(d.ctx, types.VirtualMachineConfigSpec{DeviceChange: spec})
+	if err != nil {
+		return err
+	}
+	_, err = task.WaitForResult(d.ctx, nil)
+	return err
+}
+
+// addDevice is the common Reconfigure path shared by AddNetworkAdapter and
+// AddSCSIController, both of which add a single device with no associated
+// file operation.
+func (d *Driver) addDevice(vm *object.VirtualMachine, device types.BaseVirtualDevice) error {
+	devices := object.VirtualDeviceList{device}
+	spec, err := devices.ConfigSpec(types.VirtualDeviceConfigSpecOperationAdd)
+	if err != nil {
+		return err
+	}
+
+	task, err := vm.Reconfigure(d.ctx, types.VirtualMachineConfigSpec{DeviceChange: spec})
+	if err != nil {
+		return err
+	}
+	_, err = task.WaitForResult(d.ctx, nil)
+	return err
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func TestDiskCapacityKB(t *testing.T) {
+	cases := []struct {
+		sizeGB int64
+		want   int64
+	}{
+		{sizeGB: 1, want: 1024 * 1024},
+		{sizeGB: 40, want: 40 * 1024 * 1024},
+		{sizeGB: 0, want: 0},
+	}
+
+	for _, c := range cases {
+		if got := diskCapacityKB(c.sizeGB); got != c.want {
+			t.Errorf("diskCapacityKB(%d) = %d, want %d", c.sizeGB, got, c.want)
+		}
+	}
+}
+
+func TestApplyDiskProvisioning(t *testing.T) {
+	cases := []struct {
+		provisioning string
+		wantThin     bool
+		wantEager    bool
+	}{
+		{provisioning: "thin", wantThin: true, wantEager: false},
+		{provisioning: "thick", wantThin: false, wantEager: false},
+		{provisioning: "eagerZeroedThick", wantThin: false, wantEager: true},
+		{provisioning: "", wantThin: false, wantEager: false}, // unrecognized defaults to thick
+	}
+
+	for _, c := range cases {
+		backing := &types.VirtualDiskFlatVer2BackingInfo{}
+		applyDiskProvisioning(backing, c.provisioning)
+
+		if backing.ThinProvisioned == nil || *backing.ThinProvisioned != c.wantThin {
+			t.Errorf("provisioning %q: ThinProvisioned = %v, want %v", c.provisioning, backing.ThinProvisioned, c.wantThin)
+		}
+
+		gotEager := backing.EagerlyScrub != nil && *backing.EagerlyScrub
+		if gotEager != c.wantEager {
+			t.Errorf("provisioning %q: EagerlyScrub = %v, want %v", c.provisioning, backing.EagerlyScrub, c.wantEager)
+		}
+	}
+}
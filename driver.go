@@ -10,46 +10,73 @@ import (
 	"github.com/vmware/govmomi/vim25/types"
 	"github.com/vmware/govmomi/vim25/mo"
 	"errors"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 )
 
 type Driver struct {
 	ctx        context.Context
+	cancel     context.CancelFunc
 	client     *govmomi.Client
 	datacenter *object.Datacenter
 	finder     *find.Finder
 }
 
 func NewDriverVSphere(config *ConnectConfig) (Driver, error) {
-	ctx := context.TODO()
+	ctx, cancel := context.WithCancel(context.Background())
 
 	vcenter_url, err := url.Parse(fmt.Sprintf("https://%v/sdk", config.VCenterServer))
 	if err != nil {
+		cancel()
 		return Driver{}, err
 	}
 	vcenter_url.User = url.UserPassword(config.Username, config.Password)
 	client, err := govmomi.NewClient(ctx, vcenter_url, config.InsecureConnection)
 	if err != nil {
+		cancel()
 		return Driver{}, err
 	}
 
 	finder := find.NewFinder(client.Client, false)
 	datacenter, err := finder.DatacenterOrDefault(ctx, config.Datacenter)
 	if err != nil {
+		cancel()
 		return Driver{}, err
 	}
 	finder.SetDatacenter(datacenter)
 
 	d := Driver{
 		ctx:        ctx,
+		cancel:     cancel,
 		client:     client,
 		datacenter: datacenter,
 		finder:     finder,
 	}
+	d.watchSignals()
 	return d, nil
 }
 
-func (d *Driver) cloneVM(config *CloneConfig) (*object.VirtualMachine, error) {
+// watchSignals cancels d.ctx on SIGINT/SIGTERM so an interrupted Packer
+// build cancels its in-flight vCenter tasks via runTask instead of
+// orphaning them.
+func (d *Driver) watchSignals() {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sigCh
+		d.cancel()
+	}()
+}
+
+// Cancel stops any in-flight vCenter tasks started through runTask by
+// cancelling the driver's context.
+func (d *Driver) Cancel() {
+	d.cancel()
+}
+
+func (d *Driver) cloneVM(config *CloneConfig, progressCh chan<- TaskProgress) (*object.VirtualMachine, error) {
 	vmSrc, err := d.finder.VirtualMachine(d.ctx, config.Template)
 	if err != nil {
 		return nil, err
@@ -90,6 +117,10 @@ func (d *Driver) cloneVM(config *CloneConfig) (*object.VirtualMachine, error) {
 		Location: relocateSpec,
 		PowerOn:  false,
 	}
+	if config.Customization != nil {
+		spec := config.Customization.toCustomizationSpec()
+		cloneSpec.Customization = &spec
+	}
 	if config.LinkedClone == true {
 		var vmImage mo.VirtualMachine
 		err = vmSrc.Properties(d.ctx, vmSrc.Reference(), []string{"snapshot"}, &vmImage)
@@ -103,6 +134,15 @@ func (d *Driver) cloneVM(config *CloneConfig) (*object.VirtualMachine, error) {
 		}
 		cloneSpec.Snapshot = vmImage.Snapshot.CurrentSnapshot
 	}
+	if config.StorageCluster != "" {
+		// Resolved after LinkedClone above so Storage DRS sees the real
+		// clone spec (including Snapshot), not a full-clone placement.
+		datastoreRef, err := d.recommendDatastore(vmSrc, folder, cloneSpec, config.VMName, config.StorageCluster)
+		if err != nil {
+			return nil, err
+		}
+		cloneSpec.Location.Datastore = datastoreRef
+	}
 
 	// Cloning itself
 	task, err := vmSrc.Clone(d.ctx, folder, config.VMName, cloneSpec)
@@ -110,7 +150,7 @@ func (d *Driver) cloneVM(config *CloneConfig) (*object.VirtualMachine, error) {
 		return nil, err
 	}
 
-	info, err := task.WaitForResult(d.ctx, nil)
+	info, err := d.runTask(task, "clone", progressCh)
 	if err != nil {
 		return nil, err
 	}
@@ -119,19 +159,16 @@ func (d *Driver) cloneVM(config *CloneConfig) (*object.VirtualMachine, error) {
 	return vm, nil
 }
 
-func (d *Driver) destroyVM(vm *object.VirtualMachine) error {
+func (d *Driver) destroyVM(vm *object.VirtualMachine, progressCh chan<- TaskProgress) error {
 	task, err := vm.Destroy(d.ctx)
 	if err != nil {
 		return err
 	}
-	_, err = task.WaitForResult(d.ctx, nil)
-	if err != nil {
-		return err
-	}
-	return nil
+	_, err = d.runTask(task, "destroy", progressCh)
+	return err
 }
 
-func (d *Driver) configureVM(vm *object.VirtualMachine, config *HardwareConfig) error {
+func (d *Driver) configureVM(vm *object.VirtualMachine, config *HardwareConfig, progressCh chan<- TaskProgress) error {
 	var confSpec types.VirtualMachineConfigSpec
 	confSpec.NumCPUs = config.CPUs
 	confSpec.MemoryMB = config.RAM
@@ -151,24 +188,17 @@ func (d *Driver) configureVM(vm *object.VirtualMachine, config *HardwareConfig)
 	if err != nil {
 		return err
 	}
-	_, err = task.WaitForResult(d.ctx, nil)
-	if err != nil {
-		return err
-	}
-
-	return nil
+	_, err = d.runTask(task, "configure", progressCh)
+	return err
 }
 
-func (d *Driver) powerOn(vm *object.VirtualMachine) error {
+func (d *Driver) powerOn(vm *object.VirtualMachine, progressCh chan<- TaskProgress) error {
 	task, err := vm.PowerOn(d.ctx)
 	if err != nil {
 		return err
 	}
-	_, err = task.WaitForResult(d.ctx, nil)
-	if err != nil {
-		return err
-	}
-	return nil
+	_, err = d.runTask(task, "power on", progressCh)
+	return err
 }
 
 func (d *Driver) WaitForIP(vm *object.VirtualMachine) (string, error) {
@@ -179,7 +209,7 @@ func (d *Driver) WaitForIP(vm *object.VirtualMachine) (string, error) {
 	return ip, nil
 }
 
-func (d *Driver) powerOff(vm *object.VirtualMachine) error {
+func (d *Driver) powerOff(vm *object.VirtualMachine, progressCh chan<- TaskProgress) error {
 	state, err := vm.PowerState(d.ctx)
 	if err != nil {
 		return err
@@ -193,7 +223,7 @@ func (d *Driver) powerOff(vm *object.VirtualMachine) error {
 	if err != nil {
 		return err
 	}
-	_, err = task.WaitForResult(d.ctx, nil)
+	_, err = d.runTask(task, "power off", progressCh)
 	return err
 }
 
@@ -224,15 +254,6 @@ func (d *Driver) WaitForShutdown(vm *object.VirtualMachine, timeout time.Duratio
 	return nil
 }
 
-func (d *Driver) CreateSnapshot(vm *object.VirtualMachine) error {
-	task, err := vm.CreateSnapshot(d.ctx, "Created by Packer", "", false, false)
-	if err != nil {
-		return err
-	}
-	_, err = task.WaitForResult(d.ctx, nil)
-	return err
-}
-
 func (d *Driver) ConvertToTemplate(vm *object.VirtualMachine) error {
 	err := vm.MarkAsTemplate(d.ctx)
 	return err
@@ -0,0 +1,306 @@
+package main
+
+import (
+	"archive/tar"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/vmware/govmomi/nfc"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/ovf"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// OVFConfig describes an OVF or OVA template to import into vCenter, along
+// with the placement and disk options that would otherwise come from
+// CloneConfig when starting from an inventory template.
+type OVFConfig struct {
+	// Path is either a local filesystem path or an HTTP(S) URL pointing at
+	// the .ovf/.ova to import.
+	Path string
+
+	VMName       string
+	FolderName   string
+	Host         string
+	ResourcePool string
+	Datastore    string
+
+	// NetworkMappings maps OVF network names to a Network or DVPortGroup
+	// name in the target inventory.
+	NetworkMappings map[string]string
+
+	// DeploymentOption selects one of the OVF's deployment configurations,
+	// e.g. "small"/"large". Left empty, the OVF's default is used.
+	DeploymentOption string
+
+	// DiskProvisioning controls how imported disks are laid out on the
+	// datastore: "thin", "thick", or "eagerZeroedThick".
+	DiskProvisioning string
+
+	// Properties are OVF property key/value pairs passed through to
+	// PropertyMapping on the import spec.
+	Properties map[string]string
+}
+
+// ImportOVF uploads an OVF or OVA descriptor into the configured datacenter,
+// datastore, resource pool, and folder, and returns the resulting VM. This
+// mirrors the workflow of `govc import.ovf`/`import.ova`.
+func (d *Driver) ImportOVF(config *OVFConfig) (*object.VirtualMachine, error) {
+	source := newOVFSource(config.Path)
+
+	descriptor, err := source.descriptor()
+	if err != nil {
+		return nil, fmt.Errorf("error reading OVF descriptor: %s", err)
+	}
+
+	folder, err := d.finder.FolderOrDefault(d.ctx, fmt.Sprintf("/%v/vm/%v", d.datacenter.Name(), config.FolderName))
+	if err != nil {
+		return nil, err
+	}
+
+	pool, err := d.finder.ResourcePoolOrDefault(d.ctx, fmt.Sprintf("/%v/host/%v/Resources/%v", d.datacenter.Name(), config.Host, config.ResourcePool))
+	if err != nil {
+		return nil, err
+	}
+
+	datastore, err := d.finder.DatastoreOrDefault(d.ctx, config.Datastore)
+	if err != nil {
+		return nil, err
+	}
+
+	networkMapping, err := d.resolveNetworkMappings(config.NetworkMappings)
+	if err != nil {
+		return nil, err
+	}
+
+	manager := ovf.NewManager(d.client.Client)
+	cisp := types.OvfCreateImportSpecParams{
+		EntityName:       config.VMName,
+		DiskProvisioning: config.DiskProvisioning,
+		NetworkMapping:   networkMapping,
+		PropertyMapping:  config.toPropertyMapping(),
+		OvfManagerCommonParams: types.OvfManagerCommonParams{
+			DeploymentOption: config.DeploymentOption,
+		},
+	}
+
+	spec, err := manager.CreateImportSpec(d.ctx, descriptor, pool, datastore, &cisp)
+	if err != nil {
+		return nil, err
+	}
+	if spec.Error != nil {
+		return nil, errors.New(spec.Error[0].LocalizedMessage)
+	}
+
+	lease, err := pool.ImportVApp(d.ctx, spec.ImportSpec, folder, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := lease.Wait(d.ctx, spec.FileItem)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := uploadOVFDisks(d, lease, source, info); err != nil {
+		lease.Abort(d.ctx, nil)
+		return nil, err
+	}
+
+	if err := lease.Complete(d.ctx); err != nil {
+		return nil, err
+	}
+
+	return object.NewVirtualMachine(d.client.Client, info.Entity), nil
+}
+
+func (d *Driver) resolveNetworkMappings(mappings map[string]string) ([]types.OvfNetworkMapping, error) {
+	result := make([]types.OvfNetworkMapping, 0, len(mappings))
+	for ovfName, targetName := range mappings {
+		network, err := d.finder.NetworkOrDefault(d.ctx, targetName)
+		if err != nil {
+			return nil, fmt.Errorf("error resolving network %q for OVF mapping %q: %s", targetName, ovfName, err)
+		}
+		result = append(result, types.OvfNetworkMapping{
+			Name:    ovfName,
+			Network: network.Reference(),
+		})
+	}
+	return result, nil
+}
+
+func (c *OVFConfig) toPropertyMapping() []types.KeyValue {
+	props := make([]types.KeyValue, 0, len(c.Properties))
+	for k, v := range c.Properties {
+		props = append(props, types.KeyValue{Key: k, Value: v})
+	}
+	return props
+}
+
+// ovfSource abstracts over where the OVF descriptor and its disk files come
+// from: a loose .ovf with disks alongside it, or a single .ova tar archive
+// bundling both. This mirrors the FileArchive/TapeArchive distinction in
+// govmomi's own ovf/importer package.
+type ovfSource interface {
+	descriptor() (string, error)
+	openDisk(name string) (io.ReadCloser, error)
+}
+
+func newOVFSource(path string) ovfSource {
+	if strings.HasSuffix(strings.ToLower(path), ".ova") {
+		return &ovaSource{path: path}
+	}
+	return &ovfFileSource{path: path}
+}
+
+// ovfFileSource reads a loose .ovf descriptor with its disk files sitting
+// next to it, either on the local filesystem or served over HTTP(S).
+type ovfFileSource struct {
+	path string
+}
+
+func (s *ovfFileSource) descriptor() (string, error) {
+	r, err := s.open(s.path)
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+	body, err := ioutil.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+	return string(body), nil
+}
+
+func (s *ovfFileSource) openDisk(name string) (io.ReadCloser, error) {
+	if isURL(s.path) {
+		u, err := url.Parse(s.path)
+		if err != nil {
+			return nil, err
+		}
+		u.Path = path.Join(path.Dir(u.Path), name)
+		return s.open(u.String())
+	}
+	return s.open(filepath.Join(filepath.Dir(s.path), name))
+}
+
+func (s *ovfFileSource) open(location string) (io.ReadCloser, error) {
+	if isURL(location) {
+		resp, err := http.Get(location)
+		if err != nil {
+			return nil, err
+		}
+		return resp.Body, nil
+	}
+	return os.Open(location)
+}
+
+// ovaSource reads the .ovf descriptor and disk files out of a single .ova
+// tar archive, either on the local filesystem or served over HTTP(S).
+type ovaSource struct {
+	path string
+}
+
+func (s *ovaSource) reader() (io.ReadCloser, error) {
+	if isURL(s.path) {
+		resp, err := http.Get(s.path)
+		if err != nil {
+			return nil, err
+		}
+		return resp.Body, nil
+	}
+	return os.Open(s.path)
+}
+
+func (s *ovaSource) descriptor() (string, error) {
+	r, err := s.reader()
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		if strings.HasSuffix(hdr.Name, ".ovf") {
+			body, err := ioutil.ReadAll(tr)
+			if err != nil {
+				return "", err
+			}
+			return string(body), nil
+		}
+	}
+	return "", fmt.Errorf("no .ovf descriptor found in %q", s.path)
+}
+
+func (s *ovaSource) openDisk(name string) (io.ReadCloser, error) {
+	r, err := s.reader()
+	if err != nil {
+		return nil, err
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			r.Close()
+			return nil, err
+		}
+		if hdr.Name == name {
+			return &tarEntryReader{Reader: tr, archive: r}, nil
+		}
+	}
+	r.Close()
+	return nil, fmt.Errorf("disk %q not found in %q", name, s.path)
+}
+
+// tarEntryReader lets callers Close() a single tar entry while also closing
+// the underlying archive reader/response body it was read from.
+type tarEntryReader struct {
+	io.Reader
+	archive io.Closer
+}
+
+func (t *tarEntryReader) Close() error {
+	return t.archive.Close()
+}
+
+func isURL(location string) bool {
+	return strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://")
+}
+
+// uploadOVFDisks pushes each disk referenced by the import spec's file items
+// up to vCenter through the NFC lease.
+func uploadOVFDisks(d *Driver, lease *nfc.Lease, source ovfSource, info *nfc.LeaseInfo) error {
+	for _, item := range info.Items {
+		r, err := source.openDisk(item.Path)
+		if err != nil {
+			return fmt.Errorf("error opening disk %q: %s", item.Path, err)
+		}
+
+		err = lease.Upload(d.ctx, item, r, soap.Upload{Type: "application/x-vnd.vmware-streamVmdk"})
+		r.Close()
+		if err != nil {
+			return fmt.Errorf("error uploading disk %q: %s", item.Path, err)
+		}
+	}
+	return nil
+}
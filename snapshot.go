@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// CreateSnapshot takes a named snapshot of vm. name and description are
+// recorded on the snapshot itself; memory and quiesce are passed straight
+// through to CreateSnapshot_Task.
+func (d *Driver) CreateSnapshot(vm *object.VirtualMachine, name, description string, memory, quiesce bool, progressCh chan<- TaskProgress) error {
+	task, err := vm.CreateSnapshot(d.ctx, name, description, memory, quiesce)
+	if err != nil {
+		return err
+	}
+	_, err = d.runTask(task, "create snapshot", progressCh)
+	return err
+}
+
+// ListSnapshots returns the snapshot tree for vm, or nil if it has none.
+func (d *Driver) ListSnapshots(vm *object.VirtualMachine) ([]types.VirtualMachineSnapshotTree, error) {
+	var vmMo mo.VirtualMachine
+	err := vm.Properties(d.ctx, vm.Reference(), []string{"snapshot"}, &vmMo)
+	if err != nil {
+		return nil, err
+	}
+	if vmMo.Snapshot == nil {
+		return nil, nil
+	}
+	return vmMo.Snapshot.RootSnapshotList, nil
+}
+
+// RevertToSnapshot reverts vm to the snapshot identified by nameOrRef,
+// which can be a snapshot name or its string-encoded managed object
+// reference.
+func (d *Driver) RevertToSnapshot(vm *object.VirtualMachine, nameOrRef string) error {
+	task, err := vm.RevertToSnapshot(d.ctx, nameOrRef, false)
+	if err != nil {
+		return err
+	}
+	_, err = task.WaitForResult(d.ctx, nil)
+	return err
+}
+
+// RemoveSnapshot deletes the snapshot identified by nameOrRef, optionally
+// removing its children and consolidating disks as part of the same task.
+func (d *Driver) RemoveSnapshot(vm *object.VirtualMachine, nameOrRef string, removeChildren, consolidate bool) error {
+	task, err := vm.RemoveSnapshot(d.ctx, nameOrRef, removeChildren, &consolidate)
+	if err != nil {
+		return err
+	}
+	_, err = task.WaitForResult(d.ctx, nil)
+	return err
+}
+
+// ConsolidateDisks merges any redundant delta disks left behind by snapshot
+// operations back into their base disks.
+func (d *Driver) ConsolidateDisks(vm *object.VirtualMachine) error {
+	req := types.ConsolidateVMDisks_Task{
+		This: vm.Reference(),
+	}
+	res, err := methods.ConsolidateVMDisks_Task(d.ctx, vm.Client(), &req)
+	if err != nil {
+		return err
+	}
+
+	task := object.NewTask(vm.Client(), res.Returnval)
+	_, err = task.WaitForResult(d.ctx, nil)
+	return err
+}
+
+// ensureBaseSnapshot creates a snapshot on vm if it doesn't already have one,
+// so linked_clone=true builds can promote a freshly cloned VM straight to a
+// template without requiring the caller to have taken a snapshot by hand.
+func (d *Driver) ensureBaseSnapshot(vm *object.VirtualMachine) error {
+	snapshots, err := d.ListSnapshots(vm)
+	if err != nil {
+		return err
+	}
+	if len(snapshots) > 0 {
+		return nil
+	}
+
+	if err := d.CreateSnapshot(vm, "Created by Packer", "", false, false, nil); err != nil {
+		return fmt.Errorf("error creating base snapshot for linked clone: %s", err)
+	}
+	return nil
+}
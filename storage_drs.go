@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// recommendDatastore asks Storage DRS for a placement recommendation for
+// cloneSpec against the given datastore cluster and returns the datastore it
+// selected. This mirrors the storage-pod path in govc's clone command: the
+// recommendation's destination is read directly and fed into the clone
+// spec, since ApplyStorageDrsRecommendation only applies to migration and
+// relocation recommendations, not initial-placement ones like this clone.
+func (d *Driver) recommendDatastore(vmSrc *object.VirtualMachine, folder *object.Folder, cloneSpec types.VirtualMachineCloneSpec, vmName string, storageClusterName string) (*types.ManagedObjectReference, error) {
+	pod, err := d.finder.DatastoreCluster(d.ctx, storageClusterName)
+	if err != nil {
+		return nil, fmt.Errorf("error resolving storage cluster %q: %s", storageClusterName, err)
+	}
+	podRef := pod.Reference()
+
+	sps := types.StoragePlacementSpec{
+		Type:      string(types.StoragePlacementSpecPlacementTypeClone),
+		Vm:        types.NewReference(vmSrc.Reference()),
+		CloneSpec: &cloneSpec,
+		CloneName: vmName,
+		Folder:    types.NewReference(folder.Reference()),
+		PodSelectionSpec: types.StorageDrsPodSelectionSpec{
+			StoragePod: &podRef,
+		},
+	}
+
+	srm := object.NewStorageResourceManager(d.client.Client)
+	result, err := srm.RecommendDatastores(d.ctx, sps)
+	if err != nil {
+		return nil, fmt.Errorf("error getting Storage DRS recommendation for pod %q: %s", storageClusterName, err)
+	}
+
+	if len(result.Recommendations) == 0 {
+		return nil, fmt.Errorf("Storage DRS returned no recommendations for pod %q", storageClusterName)
+	}
+
+	ref, ok := placementDestination(result.Recommendations[0])
+	if !ok {
+		return nil, fmt.Errorf("Storage DRS recommendation for pod %q had no placement action", storageClusterName)
+	}
+	return ref, nil
+}
+
+// placementDestination returns the datastore a StoragePlacementAction in
+// recommendation points at, if any.
+func placementDestination(recommendation types.ClusterRecommendation) (*types.ManagedObjectReference, bool) {
+	for _, action := range recommendation.Action {
+		if placement, ok := action.(*types.StoragePlacementAction); ok {
+			ref := placement.Destination
+			return &ref, true
+		}
+	}
+	return nil, false
+}
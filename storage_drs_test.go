@@ -0,0 +1,51 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func TestPlacementDestinationFound(t *testing.T) {
+	want := types.ManagedObjectReference{Type: "Datastore", Value: "datastore-101"}
+	recommendation := types.ClusterRecommendation{
+		Action: []types.BaseClusterAction{
+			&types.StoragePlacementAction{
+				Destination: want,
+			},
+		},
+	}
+
+	ref, ok := placementDestination(recommendation)
+
+	if !ok {
+		t.Fatal("expected a placement destination, got none")
+	}
+	if *ref != want {
+		t.Errorf("destination = %v, want %v", *ref, want)
+	}
+}
+
+func TestPlacementDestinationNoAction(t *testing.T) {
+	recommendation := types.ClusterRecommendation{}
+
+	_, ok := placementDestination(recommendation)
+
+	if ok {
+		t.Fatal("expected no placement destination for a recommendation with no actions")
+	}
+}
+
+func TestPlacementDestinationIgnoresOtherActionTypes(t *testing.T) {
+	recommendation := types.ClusterRecommendation{
+		Action: []types.BaseClusterAction{
+			&types.ClusterAction{},
+		},
+	}
+
+	_, ok := placementDestination(recommendation)
+
+	if ok {
+		t.Fatal("expected no placement destination when no action is a StoragePlacementAction")
+	}
+}
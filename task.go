@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/progress"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// TaskProgress is a single percent-done update for a running vCenter task,
+// forwarded from runTask to a caller-provided channel.
+type TaskProgress struct {
+	Name        string
+	PercentDone float32
+	Detail      string
+	Error       error
+}
+
+// runTask waits for task to complete, streaming percent-done updates to
+// progressCh (if non-nil) and honoring cancellation of d.ctx so an
+// interrupted Packer build actually cancels the in-flight vCenter task
+// instead of leaving it to run to completion orphaned. name identifies the
+// task in TaskProgress updates, e.g. "clone" or "power on".
+//
+// Updates to progressCh are abandoned once d.ctx is cancelled, so a caller
+// that stops draining progressCh after a cancellation can never make
+// runTask hang.
+func (d *Driver) runTask(task *object.Task, name string, progressCh chan<- TaskProgress) (*types.TaskInfo, error) {
+	sink := newProgressSinker(d.ctx, name, progressCh)
+	defer sink.close()
+
+	info, err := task.WaitForResult(d.ctx, sink)
+	if err != nil {
+		if progressCh != nil {
+			select {
+			case progressCh <- TaskProgress{Name: name, Error: err}:
+			case <-d.ctx.Done():
+			}
+		}
+		if d.ctx.Err() != nil {
+			if cancelErr := task.Cancel(context.Background()); cancelErr != nil {
+				return nil, cancelErr
+			}
+		}
+		return nil, err
+	}
+	return info, nil
+}
+
+// progressSinker adapts a TaskProgress channel to progress.Sinker so it can
+// be passed to task.WaitForResult.
+type progressSinker struct {
+	ctx  context.Context
+	name string
+	ch   chan<- TaskProgress
+	sink chan progress.Report
+	done chan struct{}
+}
+
+func newProgressSinker(ctx context.Context, name string, ch chan<- TaskProgress) *progressSinker {
+	s := &progressSinker{
+		ctx:  ctx,
+		name: name,
+		ch:   ch,
+		sink: make(chan progress.Report),
+		done: make(chan struct{}),
+	}
+	go s.relay()
+	return s
+}
+
+func (s *progressSinker) Sink() chan<- progress.Report {
+	return s.sink
+}
+
+// relay forwards reports from sink to ch until sink is closed or ctx is
+// cancelled. Selecting on ctx.Done() alongside the send to ch guarantees
+// relay (and therefore close, which waits on done) returns promptly even if
+// the caller stops draining ch.
+func (s *progressSinker) relay() {
+	defer close(s.done)
+	for report := range s.sink {
+		if s.ch == nil {
+			continue
+		}
+		select {
+		case s.ch <- TaskProgress{
+			Name:        s.name,
+			PercentDone: float32(report.Percentage()),
+			Detail:      report.Detail(),
+			Error:       report.Error(),
+		}:
+		case <-s.ctx.Done():
+			return
+		}
+	}
+}
+
+func (s *progressSinker) close() {
+	close(s.sink)
+	<-s.done
+}